@@ -0,0 +1,64 @@
+package clip
+
+import "GoSlice/data"
+
+// defaultMinSegmentLength is used when data.Options.Printer.MinSegmentLength
+// is not set (zero), as a last line of defense against zero-length edges.
+const defaultMinSegmentLength data.Micrometer = 10
+
+// pruneDegenerate removes consecutive points that are closer together than
+// minSegmentLength. If closed is true, path is treated as a polygon: the
+// closing edge (last point back to the first) is also checked, and the
+// result is dropped entirely if fewer than 3 points remain. If closed is
+// false, path is treated as an open polyline (e.g. a single infill line) -
+// no wraparound edge exists and a 2-point result is valid, so it is only
+// dropped if it collapses to fewer than 2 points.
+//
+// Clipper (both v1 and v2) can emit zero-length edges after offset+union
+// sequences, most commonly at the seam where an offset path closes back on
+// itself. The G-code writer and the perimeter modifier both assume
+// non-degenerate polylines, so left unfiltered these produce zero-extrusion
+// moves and, once the angle between two identical points is computed,
+// occasional NaN angles. Path.Simplify uses its own distance heuristic and
+// does not reliably catch this, so this pass runs unconditionally in
+// addition to it.
+func pruneDegenerate(path data.Path, minSegmentLength data.Micrometer, closed bool) data.Path {
+	if minSegmentLength <= 0 {
+		minSegmentLength = defaultMinSegmentLength
+	}
+
+	var result data.Path
+	for _, point := range path {
+		if len(result) > 0 && point.Sub(result[len(result)-1]).ShorterThanOrEqual(minSegmentLength) {
+			continue
+		}
+		result = append(result, point)
+	}
+
+	minPoints := 2
+	if closed {
+		// the closing edge (last point back to the first) can also collapse
+		if len(result) > 1 && result[0].Sub(result[len(result)-1]).ShorterThanOrEqual(minSegmentLength) {
+			result = result[:len(result)-1]
+		}
+		minPoints = 3
+	}
+
+	if len(result) < minPoints {
+		return nil
+	}
+
+	return result
+}
+
+// pruneDegeneratePaths runs pruneDegenerate over every path and drops the
+// ones that collapse to nil. See pruneDegenerate for the meaning of closed.
+func pruneDegeneratePaths(paths data.Paths, minSegmentLength data.Micrometer, closed bool) data.Paths {
+	var result data.Paths
+	for _, path := range paths {
+		if cleaned := pruneDegenerate(path, minSegmentLength, closed); cleaned != nil {
+			result = append(result, cleaned)
+		}
+	}
+	return result
+}
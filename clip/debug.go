@@ -0,0 +1,121 @@
+package clip
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"GoSlice/data"
+)
+
+// Debug writes the subject, clip and result paths of every GenerateLayerParts,
+// Inset and Fill call to an SVG rendering plus a JSON sidecar, one pair per
+// call, named by layer, operation and a running number. This mirrors the CLIPPER_UTILS_DEBUG / export_clipper_input_polygons_bin
+// pattern used by Slic3r-family slicers to reproduce Clipper bug reports.
+// The JSON sidecar is what cmd/goslice-replay reads back to re-run an
+// operation against a later build and diff the result.
+//
+// A nil *Debug, or one with an empty Dir, disables dumping entirely; every
+// method is safe to call on such a Debug so callers don't need to branch
+// on whether debugging is enabled.
+type Debug struct {
+	Dir string
+
+	sequence uint64
+}
+
+// NewDebug returns a Debug dumping into dir. Pass "" (e.g. the zero value
+// of the --debug-clipper-svg flag) to get a Debug whose dumps are all no-ops.
+func NewDebug(dir string) *Debug {
+	return &Debug{Dir: dir}
+}
+
+// dumpRecord is the structured recording of one clip operation. Point
+// coordinates are plain int64 pairs rather than data.MicroPoint so that it
+// round-trips through encoding/json regardless of how data.MicroPoint is
+// implemented internally.
+type dumpRecord struct {
+	Operation string       `json:"operation"`
+	LayerNr   int          `json:"layer"`
+	Subject   [][][2]int64 `json:"subject"`
+	Clip      [][][2]int64 `json:"clip,omitempty"`
+	Result    [][][2]int64 `json:"result"`
+
+	Offset            data.Micrometer `json:"offset,omitempty"`
+	InsetCount        int             `json:"insetCount,omitempty"`
+	StartOffset       data.Micrometer `json:"startOffset,omitempty"`
+	SafetyOffset      bool            `json:"safetyOffset,omitempty"`
+	LineWidth         data.Micrometer `json:"lineWidth,omitempty"`
+	OverlapPercentage int             `json:"overlapPercentage,omitempty"`
+}
+
+func toRecordPaths(paths data.Paths) [][][2]int64 {
+	var result [][][2]int64
+	for _, path := range paths {
+		var p [][2]int64
+		for _, point := range path {
+			p = append(p, [2]int64{int64(point.X()), int64(point.Y())})
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// dump writes rec's SVG rendering and JSON sidecar to
+// <dir>/layer<layerNr>-<operation>-<n>.{svg,json}.
+func (d *Debug) dump(rec dumpRecord) {
+	if d == nil || d.Dir == "" {
+		return
+	}
+
+	n := atomic.AddUint64(&d.sequence, 1)
+	base := filepath.Join(d.Dir, fmt.Sprintf("layer%04d-%s-%d", rec.LayerNr, rec.Operation, n))
+
+	if f, err := os.Create(base + ".json"); err == nil {
+		_ = json.NewEncoder(f).Encode(rec)
+		_ = f.Close()
+	}
+
+	if f, err := os.Create(base + ".svg"); err == nil {
+		writeSVG(f, rec.Subject, rec.Clip, rec.Result)
+		_ = f.Close()
+	}
+}
+
+// DumpGenerateLayerParts records a GenerateLayerParts call.
+func (d *Debug) DumpGenerateLayerParts(layerNr int, subject data.Paths, result data.Paths) {
+	d.dump(dumpRecord{
+		Operation: "generate-layer-parts",
+		LayerNr:   layerNr,
+		Subject:   toRecordPaths(subject),
+		Result:    toRecordPaths(result),
+	})
+}
+
+// DumpInset records an Inset call.
+func (d *Debug) DumpInset(layerNr int, subject data.Paths, offset data.Micrometer, insetCount int, startOffset data.Micrometer, safetyOffset bool, result data.Paths) {
+	d.dump(dumpRecord{
+		Operation:    "inset",
+		LayerNr:      layerNr,
+		Subject:      toRecordPaths(subject),
+		Result:       toRecordPaths(result),
+		Offset:       offset,
+		InsetCount:   insetCount,
+		StartOffset:  startOffset,
+		SafetyOffset: safetyOffset,
+	})
+}
+
+// DumpFill records a Fill call.
+func (d *Debug) DumpFill(layerNr int, subject data.Paths, lineWidth data.Micrometer, overlapPercentage int, result data.Paths) {
+	d.dump(dumpRecord{
+		Operation:         "fill",
+		LayerNr:           layerNr,
+		Subject:           toRecordPaths(subject),
+		Result:            toRecordPaths(result),
+		LineWidth:         lineWidth,
+		OverlapPercentage: overlapPercentage,
+	})
+}
@@ -0,0 +1,75 @@
+package clip
+
+import "GoSlice/data"
+
+// debugClipper wraps a Clipper, recording every GenerateLayerParts/Inset/Fill
+// call through a Debug hook tagged with a fixed layer number.
+type debugClipper struct {
+	Clipper
+	debug   *Debug
+	layerNr int
+}
+
+// WithDebug wraps c so that every GenerateLayerParts, InsetLayer, Inset and
+// Fill call is also recorded by debug, tagged with layerNr. Offset and
+// Difference are passed through to c unrecorded. If debug is nil or has no Dir configured, c is returned
+// unchanged, so wrapping costs nothing when --debug-clipper-svg is unset.
+func WithDebug(c Clipper, debug *Debug, layerNr int) Clipper {
+	if debug == nil || debug.Dir == "" {
+		return c
+	}
+	return debugClipper{Clipper: c, debug: debug, layerNr: layerNr}
+}
+
+func (c debugClipper) GenerateLayerParts(l data.Layer, safetyOffset bool) (data.PartitionedLayer, bool) {
+	result, ok := c.Clipper.GenerateLayerParts(l, safetyOffset)
+	if ok {
+		c.debug.DumpGenerateLayerParts(c.layerNr, l.Polygons(), flattenPartitioned(result))
+	}
+	return result, ok
+}
+
+// InsetLayer delegates to c.Inset per part, the same way the underlying
+// engines implement it, so that every inset gets recorded individually
+// instead of only the layer as a whole.
+func (c debugClipper) InsetLayer(layer data.PartitionedLayer, offset data.Micrometer, insetCount int, startOffset data.Micrometer, safetyOffset bool) [][]OffsetResult {
+	var result [][]OffsetResult
+	for _, part := range layer.LayerParts() {
+		result = append(result, c.Inset(part, offset, insetCount, startOffset, safetyOffset))
+	}
+	return result
+}
+
+func (c debugClipper) Inset(part data.LayerPart, offset data.Micrometer, insetCount int, startOffset data.Micrometer, safetyOffset bool) []OffsetResult {
+	result := c.Clipper.Inset(part, offset, insetCount, startOffset, safetyOffset)
+	c.debug.DumpInset(c.layerNr, flattenPart(part), offset, insetCount, startOffset, safetyOffset, flattenOffsetResults(result))
+	return result
+}
+
+func (c debugClipper) Fill(paths data.Paths, lineWidth data.Micrometer, overlapPercentage int) data.Paths {
+	result := c.Clipper.Fill(paths, lineWidth, overlapPercentage)
+	c.debug.DumpFill(c.layerNr, paths, lineWidth, overlapPercentage, result)
+	return result
+}
+
+func flattenPart(part data.LayerPart) data.Paths {
+	return append(data.Paths{part.Outline()}, part.Holes()...)
+}
+
+func flattenPartitioned(layer data.PartitionedLayer) data.Paths {
+	var result data.Paths
+	for _, part := range layer.LayerParts() {
+		result = append(result, flattenPart(part)...)
+	}
+	return result
+}
+
+func flattenOffsetResults(results []OffsetResult) data.Paths {
+	var result data.Paths
+	for _, r := range results {
+		for _, part := range r {
+			result = append(result, flattenPart(part)...)
+		}
+	}
+	return result
+}
@@ -0,0 +1,336 @@
+package clip
+
+import (
+	"GoSlice/data"
+	"fmt"
+	clipper "github.com/ctessum/go.clipper"
+)
+
+// clipper1Engine implements Clipper using the github.com/ctessum/go.clipper library.
+type clipper1Engine struct {
+	options *data.Options
+}
+
+// newClipper1Engine returns a new instance of a polygon Clipper backed by Clipper1.
+func newClipper1Engine(options *data.Options) Clipper {
+	return clipper1Engine{options: options}
+}
+
+// clipperPoint converts the GoSlice point representation to the
+// representation which is used by the external clipper lib.
+func clipperPoint(p data.MicroPoint) *clipper.IntPoint {
+	return &clipper.IntPoint{
+		X: clipper.CInt(p.X()),
+		Y: clipper.CInt(p.Y()),
+	}
+}
+
+// clipperPaths converts the GoSlice Paths representation
+// to the representation which is used by the external clipper lib.
+func clipperPaths(p data.Paths) clipper.Paths {
+	var result clipper.Paths
+	for _, path := range p {
+		var newPath clipper.Path
+		for _, point := range path {
+			newPath = append(newPath, clipperPoint(point))
+		}
+		result = append(result, newPath)
+	}
+
+	return result
+}
+
+// microPoint converts the external clipper lib representation of a point
+// to the representation which is used by GoSlice.
+func microPoint(p *clipper.IntPoint) data.MicroPoint {
+	return data.NewMicroPoint(data.Micrometer(p.X), data.Micrometer(p.Y))
+}
+
+// microPath converts the external clipper lib representation of a path
+// to the representation which is used by GoSlice.
+// The parameter simplify enables simplifying of the path using
+// the default simplification settings.
+// The result is always pruned of zero-length segments and near-duplicate
+// points, see pruneDegenerate - this is not optional as the perimeter
+// modifier and the G-code writer both assume non-degenerate polylines.
+// closed must be false for open polylines (e.g. infill lines), otherwise
+// the wraparound closing edge is checked and segments are dropped below 3
+// points instead of 2.
+func (c clipper1Engine) microPath(p clipper.Path, simplify bool, closed bool) data.Path {
+	var result data.Path
+	for _, point := range p {
+		result = append(result, microPoint(point))
+	}
+
+	if simplify {
+		result = result.Simplify(-1, -1)
+	}
+	return pruneDegenerate(result, c.options.Printer.MinSegmentLength, closed)
+}
+
+// microPaths converts the external clipper lib representation of paths
+// to the representation which is used by GoSlice.
+// The parameter simplify enables simplifying of the paths using
+// the default simplification settings. See microPath for closed.
+func (c clipper1Engine) microPaths(p clipper.Paths, simplify bool, closed bool) data.Paths {
+	var result data.Paths
+	for _, path := range p {
+		if cleaned := c.microPath(path, simplify, closed); cleaned != nil {
+			result = append(result, cleaned)
+		}
+	}
+	return result
+}
+
+// polyNodesToLayerParts walks a Clipper1 PolyNode tree and turns every
+// outer contour, together with its direct children as holes, into a
+// data.LayerPart. Grandchildren (islands inside holes) become new
+// top-level LayerParts, the same way GenerateLayerParts does it.
+func (c clipper1Engine) polyNodesToLayerParts(topLevel []*clipper.PolyNode) []data.LayerPart {
+	var layerParts []data.LayerPart
+
+	polysForNextRound := topLevel
+	for polysForNextRound != nil {
+		thisRound := polysForNextRound
+		polysForNextRound = nil
+
+		for _, p := range thisRound {
+			var holes data.Paths
+
+			for _, child := range p.Childs() {
+				holes = append(holes, c.microPath(child.Contour(), false, true))
+				polysForNextRound = append(polysForNextRound, child.Childs()...)
+			}
+
+			layerParts = append(layerParts, data.NewUnknownLayerPart(c.microPath(p.Contour(), false, true), holes))
+		}
+	}
+
+	return layerParts
+}
+
+// unionWithSafetyOffset runs subject through CtUnion. If safetyOffset is true,
+// subject is grown by amount first and the result is shrunk back by the same
+// amount afterwards, see safety_offset.go.
+func unionWithSafetyOffset(subject clipper.Paths, safetyOffset bool, amount data.Micrometer) (*clipper.PolyTree, bool) {
+	if safetyOffset {
+		o := clipper.NewClipperOffset()
+		o.AddPaths(subject, clipper.JtSquare, clipper.EtClosedPolygon)
+		o.MiterLimit = 2
+		subject = o.Execute(float64(amount))
+	}
+
+	cl := clipper.NewClipper(clipper.IoNone)
+	cl.AddPaths(subject, clipper.PtSubject, true)
+	tree, ok := cl.Execute2(clipper.CtUnion, clipper.PftEvenOdd, clipper.PftEvenOdd)
+	if !ok {
+		return nil, false
+	}
+
+	if safetyOffset {
+		var shrunk clipper.Paths
+		var flatten func(nodes []*clipper.PolyNode)
+		flatten = func(nodes []*clipper.PolyNode) {
+			for _, p := range nodes {
+				shrunk = append(shrunk, p.Contour())
+				flatten(p.Childs())
+			}
+		}
+		flatten(tree.Childs())
+
+		o := clipper.NewClipperOffset()
+		o.AddPaths(shrunk, clipper.JtSquare, clipper.EtClosedPolygon)
+		o.MiterLimit = 2
+		shrunk = o.Execute(-float64(amount))
+
+		cl = clipper.NewClipper(clipper.IoNone)
+		cl.AddPaths(shrunk, clipper.PtSubject, true)
+		return cl.Execute2(clipper.CtUnion, clipper.PftEvenOdd, clipper.PftEvenOdd)
+	}
+
+	return tree, true
+}
+
+func (c clipper1Engine) GenerateLayerParts(l data.Layer, safetyOffset bool) (data.PartitionedLayer, bool) {
+	polyList := clipper.Paths{}
+	// convert all polygons to clipper polygons
+	for _, layerPolygon := range l.Polygons() {
+		var path = clipper.Path{}
+
+		prev := 0
+		// convert all points of this polygons
+		for j, layerPoint := range layerPolygon {
+			// ignore first as the next check would fail otherwise
+			if j == 0 {
+				path = append(path, clipperPoint(layerPolygon[0]))
+				continue
+			}
+
+			// filter too near points
+			// check this always with the previous point
+			if layerPoint.Sub(layerPolygon[prev]).ShorterThanOrEqual(100) {
+				continue
+			}
+
+			path = append(path, clipperPoint(layerPoint))
+			prev = j
+		}
+
+		polyList = append(polyList, path)
+	}
+
+	tree, ok := unionWithSafetyOffset(polyList, safetyOffset, safetyOffsetDistance)
+	if !ok {
+		return nil, false
+	}
+
+	return data.NewPartitionedLayer(c.polyNodesToLayerParts(tree.Childs())), true
+}
+
+func (c clipper1Engine) InsetLayer(layer data.PartitionedLayer, offset data.Micrometer, insetCount int, startOffset data.Micrometer, safetyOffset bool) [][]OffsetResult {
+	var result [][]OffsetResult
+	for _, part := range layer.LayerParts() {
+		result = append(result, c.Inset(part, offset, insetCount, startOffset, safetyOffset))
+	}
+
+	return result
+}
+
+func (c clipper1Engine) Inset(part data.LayerPart, offset data.Micrometer, insetCount int, startOffset data.Micrometer, safetyOffset bool) []OffsetResult {
+	var insets []OffsetResult
+
+	o := clipper.NewClipperOffset()
+
+	for insetNr := 0; insetNr < insetCount; insetNr++ {
+		o.Clear()
+		o.AddPaths(clipperPaths(data.Paths{part.Outline()}), clipper.JtSquare, clipper.EtClosedPolygon)
+		o.AddPaths(clipperPaths(part.Holes()), clipper.JtSquare, clipper.EtClosedPolygon)
+
+		o.MiterLimit = 2
+		allNewInsets := o.Execute(float64(-int(offset)*insetNr) + float64(startOffset))
+
+		if len(allNewInsets) <= 0 {
+			break
+		}
+
+		// Clipper1's offsetter returns flat paths without nesting information,
+		// so run them through a union to recover which contours are holes of
+		// which outlines before turning them into LayerParts.
+		tree, ok := unionWithSafetyOffset(allNewInsets, safetyOffset, safetyOffsetDistance)
+		if !ok {
+			break
+		}
+
+		insets = append(insets, OffsetResult(c.polyNodesToLayerParts(tree.Childs())))
+	}
+
+	return insets
+}
+
+func (c clipper1Engine) Fill(paths data.Paths, lineWidth data.Micrometer, overlapPercentage int) data.Paths {
+	min, max := paths.Size()
+	cPaths := clipperPaths(paths)
+	result := c.getLinearFill(cPaths, min, max, lineWidth, overlapPercentage)
+	return c.microPaths(result, false, false)
+}
+
+func (c clipper1Engine) Offset(paths data.Paths, delta data.Micrometer) data.Paths {
+	o := clipper.NewClipperOffset()
+	o.AddPaths(clipperPaths(paths), clipper.JtSquare, clipper.EtClosedPolygon)
+	o.MiterLimit = 2
+	return c.microPaths(o.Execute(float64(delta)), false, true)
+}
+
+func (c clipper1Engine) Difference(subject data.Paths, clipPaths data.Paths) data.Paths {
+	cl := clipper.NewClipper(clipper.IoNone)
+	cl.AddPaths(clipperPaths(subject), clipper.PtSubject, true)
+	cl.AddPaths(clipperPaths(clipPaths), clipper.PtClip, true)
+
+	tree, ok := cl.Execute2(clipper.CtDifference, clipper.PftEvenOdd, clipper.PftEvenOdd)
+	if !ok {
+		return nil
+	}
+
+	var result clipper.Paths
+	var flatten func(nodes []*clipper.PolyNode)
+	flatten = func(nodes []*clipper.PolyNode) {
+		for _, n := range nodes {
+			result = append(result, n.Contour())
+			flatten(n.Childs())
+		}
+	}
+	flatten(tree.Childs())
+
+	return c.microPaths(result, false, true)
+}
+
+// getLinearFill provides a infill which uses simple parallel lines
+func (c clipper1Engine) getLinearFill(polys clipper.Paths, minScanlines data.MicroPoint, maxScanlines data.MicroPoint, lineWidth data.Micrometer, overlapPercentage int) clipper.Paths {
+	cl := clipper.NewClipper(clipper.IoNone)
+	co := clipper.NewClipperOffset()
+	var result clipper.Paths
+
+	overlap := float32(lineWidth) * (100.0 - float32(overlapPercentage)) / 100.0
+
+	lines := clipper.Paths{}
+	numLine := 0
+	// generate the lines
+	for x := minScanlines.X(); x <= maxScanlines.X(); x += lineWidth {
+		// switch line direction based on even / odd
+		if numLine%2 == 1 {
+			lines = append(lines, clipper.Path{
+				&clipper.IntPoint{
+					X: clipper.CInt(x),
+					Y: clipper.CInt(maxScanlines.Y()),
+				},
+				&clipper.IntPoint{
+					X: clipper.CInt(x),
+					Y: clipper.CInt(minScanlines.Y()),
+				},
+			})
+		} else {
+			lines = append(lines, clipper.Path{
+				&clipper.IntPoint{
+					X: clipper.CInt(x),
+					Y: clipper.CInt(minScanlines.Y()),
+				},
+				&clipper.IntPoint{
+					X: clipper.CInt(x),
+					Y: clipper.CInt(maxScanlines.Y()),
+				},
+			})
+		}
+		numLine++
+	}
+
+	// clip the paths with the lines using intersection
+	for _, path := range polys {
+		inset := clipper.Paths{path}
+
+		// generate the inset for the overlap (only if needed)
+		if overlapPercentage != 0 {
+			co.AddPaths(inset, clipper.JtSquare, clipper.EtClosedPolygon)
+			co.MiterLimit = 2
+			inset = co.Execute(float64(-overlap))
+		}
+
+		// clip the lines by the resulting inset
+		cl.AddPaths(inset, clipper.PtClip, true)
+		cl.AddPaths(lines, clipper.PtSubject, false)
+
+		tree, ok := cl.Execute2(clipper.CtIntersection, clipper.PftEvenOdd, clipper.PftEvenOdd)
+		if !ok {
+			fmt.Println("getLinearFill failed")
+			return nil
+		}
+
+		for _, c := range tree.Childs() {
+			result = append(result, c.Contour())
+		}
+
+		cl.Clear()
+		co.Clear()
+	}
+
+	return result
+}
@@ -0,0 +1,277 @@
+package clip
+
+import (
+	"GoSlice/data"
+
+	clipper2 "github.com/ctessum/go.clipper2"
+)
+
+// clipper2Engine implements Clipper using the github.com/ctessum/go.clipper2 library.
+type clipper2Engine struct {
+	options *data.Options
+}
+
+// newClipper2Engine returns a new instance of a polygon Clipper backed by Clipper2.
+func newClipper2Engine(options *data.Options) Clipper {
+	return clipper2Engine{options: options}
+}
+
+// c2Point converts the GoSlice point representation to the
+// representation which is used by the Clipper2 lib.
+func c2Point(p data.MicroPoint) clipper2.Point64 {
+	return clipper2.Point64{X: int64(p.X()), Y: int64(p.Y())}
+}
+
+// c2Path converts a GoSlice Path to a Clipper2 Path64, dropping points that
+// lie within 100 micrometers of the previously kept point. This is the same
+// filter the Clipper1 engine applies in GenerateLayerParts, but here it is
+// applied to every path this engine converts.
+func c2Path(p data.Path) clipper2.Path64 {
+	var result clipper2.Path64
+
+	prev := 0
+	for i, point := range p {
+		if i == 0 {
+			result = append(result, c2Point(point))
+			continue
+		}
+
+		if point.Sub(p[prev]).ShorterThanOrEqual(100) {
+			continue
+		}
+
+		result = append(result, c2Point(point))
+		prev = i
+	}
+
+	return result
+}
+
+// c2Paths converts the GoSlice Paths representation to a Clipper2 Paths64.
+func c2Paths(p data.Paths) clipper2.Paths64 {
+	var result clipper2.Paths64
+	for _, path := range p {
+		result = append(result, c2Path(path))
+	}
+	return result
+}
+
+// microPoint2 converts a Clipper2 point back to the GoSlice representation.
+func microPoint2(p clipper2.Point64) data.MicroPoint {
+	return data.NewMicroPoint(data.Micrometer(p.X), data.Micrometer(p.Y))
+}
+
+// microPath2 converts a Clipper2 Path64 back to a GoSlice Path.
+// The result is always pruned of zero-length segments and near-duplicate
+// points, see pruneDegenerate - this is not optional as the perimeter
+// modifier and the G-code writer both assume non-degenerate polylines.
+// closed must be false for open polylines (e.g. infill lines), otherwise
+// the wraparound closing edge is checked and segments are dropped below 3
+// points instead of 2.
+func (c clipper2Engine) microPath2(p clipper2.Path64, simplify bool, closed bool) data.Path {
+	var result data.Path
+	for _, point := range p {
+		result = append(result, microPoint2(point))
+	}
+
+	if simplify {
+		result = result.Simplify(-1, -1)
+	}
+	return pruneDegenerate(result, c.options.Printer.MinSegmentLength, closed)
+}
+
+// microPaths2 converts a Clipper2 Paths64 back to GoSlice Paths. See
+// microPath2 for closed.
+func (c clipper2Engine) microPaths2(p clipper2.Paths64, simplify bool, closed bool) data.Paths {
+	var result data.Paths
+	for _, path := range p {
+		if cleaned := c.microPath2(path, simplify, closed); cleaned != nil {
+			result = append(result, cleaned)
+		}
+	}
+	return result
+}
+
+// polyPathsToLayerParts walks a Clipper2 PolyTree64 and turns every outer
+// contour, together with its direct children as holes, into a
+// data.LayerPart. Grandchildren (islands inside holes) become new
+// top-level LayerParts. Clipper2's PolyTree64 exposes its children
+// through Count()/Child(i) instead of Clipper1's Childs() slice, so the
+// traversal is shaped slightly differently even though the result is
+// identical to the Clipper1 engine's.
+func (c clipper2Engine) polyPathsToLayerParts(node *clipper2.PolyPath64) []data.LayerPart {
+	var layerParts []data.LayerPart
+
+	polysForNextRound := make([]*clipper2.PolyPath64, node.Count())
+	for i := 0; i < node.Count(); i++ {
+		polysForNextRound[i] = node.Child(i)
+	}
+
+	for polysForNextRound != nil {
+		thisRound := polysForNextRound
+		polysForNextRound = nil
+
+		for _, p := range thisRound {
+			var holes data.Paths
+
+			for i := 0; i < p.Count(); i++ {
+				child := p.Child(i)
+				holes = append(holes, c.microPath2(child.Polygon(), false, true))
+
+				for j := 0; j < child.Count(); j++ {
+					polysForNextRound = append(polysForNextRound, child.Child(j))
+				}
+			}
+
+			layerParts = append(layerParts, data.NewUnknownLayerPart(c.microPath2(p.Polygon(), false, true), holes))
+		}
+	}
+
+	return layerParts
+}
+
+// unionWithSafetyOffset64 runs subject through a Clipper2 union into tree.
+// If safetyOffset is true, subject is grown by amount first via InflatePaths
+// and the result is shrunk back by the same amount afterwards, see
+// safety_offset.go.
+func unionWithSafetyOffset64(subject clipper2.Paths64, safetyOffset bool, amount data.Micrometer, tree *clipper2.PolyTree64) bool {
+	if safetyOffset {
+		subject = clipper2.InflatePaths(subject, float64(amount), clipper2.JoinSquare, clipper2.EndPolygon, 2)
+	}
+
+	cl := clipper2.NewClipper64()
+	cl.AddSubject(subject)
+	if !cl.Execute(clipper2.Union, clipper2.EvenOdd, tree) {
+		return false
+	}
+
+	if !safetyOffset {
+		return true
+	}
+
+	shrunk := clipper2.InflatePaths(clipper2.PolyTreeToPaths64(tree), -float64(amount), clipper2.JoinSquare, clipper2.EndPolygon, 2)
+
+	cl = clipper2.NewClipper64()
+	cl.AddSubject(shrunk)
+	return cl.Execute(clipper2.Union, clipper2.EvenOdd, tree)
+}
+
+func (c clipper2Engine) GenerateLayerParts(l data.Layer, safetyOffset bool) (data.PartitionedLayer, bool) {
+	var polyList clipper2.Paths64
+	for _, layerPolygon := range l.Polygons() {
+		polyList = append(polyList, c2Path(layerPolygon))
+	}
+
+	tree := clipper2.NewPolyTree64()
+	if !unionWithSafetyOffset64(polyList, safetyOffset, safetyOffsetDistance, tree) {
+		return nil, false
+	}
+
+	return data.NewPartitionedLayer(c.polyPathsToLayerParts(tree.PolyPath64)), true
+}
+
+func (c clipper2Engine) InsetLayer(layer data.PartitionedLayer, offset data.Micrometer, insetCount int, startOffset data.Micrometer, safetyOffset bool) [][]OffsetResult {
+	var result [][]OffsetResult
+	for _, part := range layer.LayerParts() {
+		result = append(result, c.Inset(part, offset, insetCount, startOffset, safetyOffset))
+	}
+
+	return result
+}
+
+func (c clipper2Engine) Inset(part data.LayerPart, offset data.Micrometer, insetCount int, startOffset data.Micrometer, safetyOffset bool) []OffsetResult {
+	var insets []OffsetResult
+
+	subject := append(clipper2.Paths64{c2Path(part.Outline())}, c2Paths(part.Holes())...)
+
+	for insetNr := 0; insetNr < insetCount; insetNr++ {
+		delta := float64(-int(offset)*insetNr) + float64(startOffset)
+
+		inflated := clipper2.InflatePaths(subject, delta, clipper2.JoinSquare, clipper2.EndPolygon, 2)
+		if len(inflated) == 0 {
+			break
+		}
+
+		// Re-union the offset result through a PolyTree so that holes
+		// introduced or closed by the offset are classified correctly -
+		// this is the PolyTree traversal Clipper2 was chosen for.
+		tree := clipper2.NewPolyTree64()
+		if !unionWithSafetyOffset64(inflated, safetyOffset, safetyOffsetDistance, tree) {
+			break
+		}
+
+		insets = append(insets, OffsetResult(c.polyPathsToLayerParts(tree.PolyPath64)))
+	}
+
+	return insets
+}
+
+func (c clipper2Engine) Fill(paths data.Paths, lineWidth data.Micrometer, overlapPercentage int) data.Paths {
+	min, max := paths.Size()
+	result := c.getLinearFill(c2Paths(paths), min, max, lineWidth, overlapPercentage)
+	return c.microPaths2(result, false, false)
+}
+
+func (c clipper2Engine) Offset(paths data.Paths, delta data.Micrometer) data.Paths {
+	inflated := clipper2.InflatePaths(c2Paths(paths), float64(delta), clipper2.JoinSquare, clipper2.EndPolygon, 2)
+	return c.microPaths2(inflated, false, true)
+}
+
+func (c clipper2Engine) Difference(subject data.Paths, clipPaths data.Paths) data.Paths {
+	cl := clipper2.NewClipper64()
+	cl.AddSubject(c2Paths(subject))
+	cl.AddClip(c2Paths(clipPaths))
+
+	tree := clipper2.NewPolyTree64()
+	if !cl.Execute(clipper2.Difference, clipper2.EvenOdd, tree) {
+		return nil
+	}
+
+	return c.microPaths2(clipper2.PolyTreeToPaths64(tree), false, true)
+}
+
+// getLinearFill provides a infill which uses simple parallel lines.
+func (c clipper2Engine) getLinearFill(polys clipper2.Paths64, minScanlines data.MicroPoint, maxScanlines data.MicroPoint, lineWidth data.Micrometer, overlapPercentage int) clipper2.Paths64 {
+	var result clipper2.Paths64
+
+	overlap := float64(lineWidth) * (100.0 - float64(overlapPercentage)) / 100.0
+
+	var lines clipper2.Paths64
+	numLine := 0
+	for x := minScanlines.X(); x <= maxScanlines.X(); x += lineWidth {
+		if numLine%2 == 1 {
+			lines = append(lines, clipper2.Path64{
+				{X: int64(x), Y: int64(maxScanlines.Y())},
+				{X: int64(x), Y: int64(minScanlines.Y())},
+			})
+		} else {
+			lines = append(lines, clipper2.Path64{
+				{X: int64(x), Y: int64(minScanlines.Y())},
+				{X: int64(x), Y: int64(maxScanlines.Y())},
+			})
+		}
+		numLine++
+	}
+
+	for _, path := range polys {
+		inset := clipper2.Paths64{path}
+
+		if overlapPercentage != 0 {
+			inset = clipper2.InflatePaths(inset, -overlap, clipper2.JoinSquare, clipper2.EndPolygon, 2)
+		}
+
+		cl := clipper2.NewClipper64()
+		cl.AddClip(inset)
+		cl.AddOpenSubject(lines)
+
+		tree := clipper2.NewPolyTree64()
+		open := clipper2.Paths64{}
+		if !cl.ExecuteOpen(clipper2.Intersection, clipper2.EvenOdd, tree, &open) {
+			continue
+		}
+
+		result = append(result, open...)
+	}
+
+	return result
+}
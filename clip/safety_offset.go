@@ -0,0 +1,21 @@
+package clip
+
+import "GoSlice/data"
+
+// safetyOffsetDistance is the distance used for the safety-offset pre-pass
+// applied before boolean operations.
+//
+// This is the well-known Slic3r/PrusaSlicer workaround for Clipper's
+// handling of near-coincident edges and shared vertices: offsetting the
+// operands outward by a tiny, sub-visible amount before a union or
+// intersection nudges shared edges apart so Clipper can no longer confuse
+// them for duplicates, and the offset is undone on the result afterwards.
+//
+// A fixed distance is used rather than one scaled off the extrusion width:
+// GoSlice feeds data.Micrometer coordinates straight to Clipper with no
+// further internal scaling, so there is no unit in which a small fraction
+// of a realistic extrusion width (e.g. 400µm) would reliably round to
+// something nonzero - any such factor is effectively always the floor
+// value anyway. 10µm is sub-visible at any print resolution this slicer
+// targets, which is all the pre-pass needs.
+const safetyOffsetDistance data.Micrometer = 10
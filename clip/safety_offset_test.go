@@ -0,0 +1,9 @@
+package clip
+
+import "testing"
+
+func TestSafetyOffsetDistanceIsNonzero(t *testing.T) {
+	if safetyOffsetDistance <= 0 {
+		t.Fatalf("safetyOffsetDistance = %d, want > 0", safetyOffsetDistance)
+	}
+}
@@ -0,0 +1,67 @@
+package clip
+
+import (
+	"fmt"
+	"io"
+)
+
+// writeSVG renders subject (blue outline), clipPaths (red outline) and
+// result (filled green) path groups into a simple standalone SVG document,
+// scaled to fit their combined bounds. clipPaths may be empty for unary
+// operations such as GenerateLayerParts and Inset.
+func writeSVG(w io.Writer, subject, clipPaths, result [][][2]int64) {
+	minX, minY, maxX, maxY := boundsOf(subject, clipPaths, result)
+	width := maxX - minX
+	height := maxY - minY
+	if width == 0 {
+		width = 1
+	}
+	if height == 0 {
+		height = 1
+	}
+
+	_, _ = fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" viewBox=\"%d %d %d %d\">\n", minX, minY, width, height)
+	writeGroup(w, subject, "none", "blue")
+	writeGroup(w, clipPaths, "none", "red")
+	writeGroup(w, result, "rgba(0,160,0,0.3)", "green")
+	_, _ = fmt.Fprint(w, "</svg>\n")
+}
+
+func boundsOf(groups ...[][][2]int64) (minX, minY, maxX, maxY int64) {
+	first := true
+	for _, group := range groups {
+		for _, path := range group {
+			for _, point := range path {
+				if first {
+					minX, maxX = point[0], point[0]
+					minY, maxY = point[1], point[1]
+					first = false
+					continue
+				}
+				if point[0] < minX {
+					minX = point[0]
+				}
+				if point[0] > maxX {
+					maxX = point[0]
+				}
+				if point[1] < minY {
+					minY = point[1]
+				}
+				if point[1] > maxY {
+					maxY = point[1]
+				}
+			}
+		}
+	}
+	return
+}
+
+func writeGroup(w io.Writer, paths [][][2]int64, fill, stroke string) {
+	for _, path := range paths {
+		_, _ = fmt.Fprintf(w, "<polygon fill=\"%s\" stroke=\"%s\" points=\"", fill, stroke)
+		for _, point := range path {
+			_, _ = fmt.Fprintf(w, "%d,%d ", point[0], point[1])
+		}
+		_, _ = fmt.Fprint(w, "\"/>\n")
+	}
+}
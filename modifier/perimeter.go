@@ -2,14 +2,18 @@ package modifier
 
 import (
 	"errors"
+	"runtime"
+
 	"github.com/aligator/goslice/clip"
 	"github.com/aligator/goslice/data"
 	"github.com/aligator/goslice/handler"
+	"golang.org/x/sync/errgroup"
 )
 
 type perimeterModifier struct {
 	handler.Named
 	options *data.Options
+	debug   *clip.Debug
 }
 
 // NewPerimeterModifier creates a modifier which calculates all perimeters
@@ -21,6 +25,7 @@ func NewPerimeterModifier(options *data.Options) handler.LayerModifier {
 			Name: "Perimeter",
 		},
 		options: options,
+		debug:   clip.NewDebug(options.GoSlice.DebugClipperSVG),
 	}
 }
 
@@ -44,10 +49,11 @@ func OverlapPerimeters(layer data.PartitionedLayer) ([][]data.LayerPart, error)
 // Perimeters extracts the attribute "perimeters" from the layer.
 // If it has the wrong type, a error is returned.
 // If it doesn't exist, (nil, nil) is returned.
-// If it exists, the perimeters are returned.
-func Perimeters(layer data.PartitionedLayer) (clip.OffsetResult, error) {
+// If it exists, the perimeters are returned as [part][insetNr]clip.OffsetResult,
+// the same shape clip.Clipper.InsetLayer returns.
+func Perimeters(layer data.PartitionedLayer) ([][]clip.OffsetResult, error) {
 	if attr, ok := layer.Attributes()["perimeters"]; ok {
-		perimeters, ok := attr.(clip.OffsetResult)
+		perimeters, ok := attr.([][]clip.OffsetResult)
 		if !ok {
 			return nil, errors.New("the attribute perimeters has the wrong datatype")
 		}
@@ -60,52 +66,108 @@ func Perimeters(layer data.PartitionedLayer) (clip.OffsetResult, error) {
 
 func (m perimeterModifier) Init(_ data.OptimizedModel) {}
 
+// Modify dispatches the per-layer perimeter calculation across a pool of
+// goroutines sized by options.GoSlice.ParallelLayers (falling back to
+// runtime.NumCPU()). Every layer is independent - each goroutine builds its
+// own clip.Clipper and only ever writes to its own layers[layerNr] slot - so
+// this requires no further synchronization beyond the errgroup used to
+// propagate the first error and wait for completion.
 func (m perimeterModifier) Modify(layers []data.PartitionedLayer) error {
+	parallelLayers := m.options.GoSlice.ParallelLayers
+	if parallelLayers <= 0 {
+		parallelLayers = runtime.NumCPU()
+	}
+
+	g := new(errgroup.Group)
+	tokens := make(chan struct{}, parallelLayers)
+
 	for layerNr := range layers {
-		// Generate the perimeters.
-		c := clip.NewClipper()
-		insetParts := c.InsetLayer(layers[layerNr].LayerParts(), m.options.Printer.ExtrusionWidth, m.options.Print.InsetCount, -m.options.Printer.ExtrusionWidth/2)
+		layerNr := layerNr
+
+		tokens <- struct{}{}
+		g.Go(func() error {
+			defer func() { <-tokens }()
+			return m.modifyLayer(layers, layerNr)
+		})
+	}
 
-		// Also generate the overlapping perimeter, which helps with calculating the infill.
-		// This is derived from the most inner perimeters and offset by the options.Print.InfillOverlapPercent option.
+	return g.Wait()
+}
 
-		var overlapPerimeter [][]data.LayerPart
+// modifyLayer computes perimeters, overlap perimeters and variable-width
+// gap-fill for a single layer and writes the result into layers[layerNr].
+func (m perimeterModifier) modifyLayer(layers []data.PartitionedLayer, layerNr int) error {
+	c := clip.WithDebug(clip.NewClipper(m.options), m.debug, layerNr)
 
-		for partNr, part := range insetParts {
-			if len(overlapPerimeter) >= partNr {
-				overlapPerimeter = append(overlapPerimeter, nil)
-			}
+	// With more than one inset wall, roundoff from repeated offsetting is
+	// what usually causes inner walls to lose contact with the outer wall
+	// at sharp corners, so only pay for the safety offset pass when needed.
+	safetyOffset := m.options.Print.InsetCount > 1
+	insetParts := c.InsetLayer(layers[layerNr].LayerParts(), m.options.Printer.ExtrusionWidth, m.options.Print.InsetCount, -m.options.Printer.ExtrusionWidth/2, safetyOffset)
+
+	// Also generate the overlapping perimeter, which helps with calculating the infill.
+	// This is derived from the most inner perimeters and offset by the options.Print.InfillOverlapPercent option.
+
+	var overlapPerimeter [][]data.LayerPart
+	var variableWidthPerimeters [][]VariableWidthPolyline
 
-			// Use only the most inner perimeter.
-			for _, insetPart := range part[len(part)-1] {
+	for partNr, part := range insetParts {
+		if len(overlapPerimeter) >= partNr {
+			overlapPerimeter = append(overlapPerimeter, nil)
+			variableWidthPerimeters = append(variableWidthPerimeters, nil)
+		}
+
+		if len(part) == 0 {
+			// The part is thinner than one inset wall - Inset broke before
+			// producing any level at all, so there is no inner perimeter to
+			// derive an overlap border from. gapFillRegion below finds
+			// nothing in such a part either, as its half-width inset is
+			// equally empty, so the part gets neither perimeters nor gap-fill.
+			continue
+		}
 
-				maxOverlapBorder, err := calculateOverlapPerimeter(insetPart, m.options.Print.InfillOverlapPercent, m.options.Printer.ExtrusionWidth)
-				if err != nil {
-					return err
-				}
-				overlapPerimeter[partNr] = append(overlapPerimeter[partNr], maxOverlapBorder...)
+		// Use only the most inner perimeter.
+		for _, insetPart := range part[len(part)-1] {
+
+			maxOverlapBorder, err := calculateOverlapPerimeter(c, insetPart, m.options.Print.InfillOverlapPercent, m.options.Printer.ExtrusionWidth)
+			if err != nil {
+				return err
 			}
+			overlapPerimeter[partNr] = append(overlapPerimeter[partNr], maxOverlapBorder...)
 		}
+	}
 
-		newLayer := newExtendedLayer(layers[layerNr])
-		newLayer.attributes["perimeters"] = insetParts
-		newLayer.attributes["overlapPerimeters"] = overlapPerimeter
-		layers[layerNr] = newLayer
+	for partNr, part := range layers[layerNr].LayerParts() {
+		gapFill := gapFillRegion(c, part, m.options.Printer.ExtrusionWidth)
+		variableWidthPerimeters[partNr] = append(variableWidthPerimeters[partNr], skeletonize(c, gapFill, m.options.Printer.ExtrusionWidth)...)
 	}
 
+	newLayer := newExtendedLayer(layers[layerNr])
+	newLayer.attributes["perimeters"] = insetParts
+	newLayer.attributes["overlapPerimeters"] = overlapPerimeter
+	newLayer.attributes["variableWidthPerimeters"] = variableWidthPerimeters
+	layers[layerNr] = newLayer
+
 	return nil
 }
 
 // calculateOverlapPerimeter helper function for calculating the overlap-perimeter out of a layer part.
-func calculateOverlapPerimeter(part data.LayerPart, overlapPercent int, extrusionWidth data.Micrometer) ([]data.LayerPart, error) {
+func calculateOverlapPerimeter(c clip.Clipper, part data.LayerPart, overlapPercent int, extrusionWidth data.Micrometer) ([]data.LayerPart, error) {
 	perimeterOverlap := data.Micrometer(float32(extrusionWidth) * (100.0 - float32(overlapPercent)) / 100.0)
 
-	if perimeterOverlap != 0 {
-		c := clip.NewClipper()
-		// As we use only one inset, just return index 0.
-		return c.Inset(part, perimeterOverlap, 1, -perimeterOverlap/2)[0], nil
-	} else {
+	if perimeterOverlap == 0 {
 		// If no overlap needed, just return the input part.
 		return []data.LayerPart{part}, nil
 	}
+
+	insets := c.Inset(part, perimeterOverlap, 1, -perimeterOverlap/2, false)
+	if len(insets) == 0 {
+		// part is thinner than the overlap inset - there is nothing to
+		// trim, so fall back to the untouched part rather than indexing
+		// into an empty result.
+		return []data.LayerPart{part}, nil
+	}
+
+	// As we use only one inset, just return index 0.
+	return insets[0], nil
 }
@@ -0,0 +1,169 @@
+package modifier
+
+import (
+	"errors"
+
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+)
+
+// VariableWidthPoint is one vertex of a variable-width gap-fill polyline.
+// Width is the extrusion width the G-code writer should use at this point,
+// always within [0.5, 1.5] * the nominal extrusion width.
+type VariableWidthPoint struct {
+	Point data.MicroPoint
+	Width data.Micrometer
+}
+
+// VariableWidthPolyline is an open polyline of gap-fill extrusion that
+// bridges a region too thin for a full insetCount perimeters to fit into.
+type VariableWidthPolyline []VariableWidthPoint
+
+// VariableWidthPerimeters extracts the attribute "variableWidthPerimeters" from the layer.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+// If it exists, the gap-fill polylines are returned as [part][]VariableWidthPolyline.
+func VariableWidthPerimeters(layer data.PartitionedLayer) ([][]VariableWidthPolyline, error) {
+	if attr, ok := layer.Attributes()["variableWidthPerimeters"]; ok {
+		perimeters, ok := attr.([][]VariableWidthPolyline)
+		if !ok {
+			return nil, errors.New("the attribute variableWidthPerimeters has the wrong datatype")
+		}
+
+		return perimeters, nil
+	}
+
+	return nil, nil
+}
+
+// outlinesOf flattens the parts of an OffsetResult into a single data.Paths
+// containing every part's outline and holes.
+func outlinesOf(parts clip.OffsetResult) data.Paths {
+	var result data.Paths
+	for _, part := range parts {
+		result = append(result, part.Outline())
+		result = append(result, part.Holes()...)
+	}
+	return result
+}
+
+// gapFillRegion computes the area that a single extrusionWidth-wide wall
+// does not reach: the outline inset by half a width, minus the same
+// outline inset by one and a half widths and offset back out by one width.
+// What remains is too thin to ever get a wall of its own, no matter how
+// many insets are computed, and is exactly the area PrusaSlicer/SuperSlicer
+// bridge with variable-width gap-fill instead of leaving empty.
+func gapFillRegion(c clip.Clipper, part data.LayerPart, extrusionWidth data.Micrometer) data.Paths {
+	outer := c.Inset(part, extrusionWidth, 1, -extrusionWidth/2, false)
+	if len(outer) == 0 {
+		return nil
+	}
+
+	inner := c.Inset(part, extrusionWidth, 1, -(3*extrusionWidth)/2, false)
+	if len(inner) == 0 {
+		// Nothing survives insetting 1.5 widths - the whole part is thin,
+		// not just a pinch point within it, so there is nothing to subtract
+		// and the whole half-width inset is the gap-fill region.
+		return outlinesOf(outer[0])
+	}
+
+	var reGrown data.Paths
+	for _, innerPart := range inner[0] {
+		reGrown = append(reGrown, c.Offset(outlinesOf(clip.OffsetResult{innerPart}), extrusionWidth)...)
+	}
+
+	return c.Difference(outlinesOf(outer[0]), reGrown)
+}
+
+// skeletonizeSteps is the number of inward offset steps used to approximate
+// the medial axis of a thin region, see skeletonize.
+const skeletonizeSteps = 8
+
+// skeletonContour tracks one connected component of region as it is
+// repeatedly offset inward. Every contour is offset on its own, rather than
+// offsetting the whole region together, so that a narrow contour vanishing
+// early is recorded at its own depth instead of being carried along by a
+// wider sibling that survives longer.
+type skeletonContour struct {
+	path data.Path
+}
+
+// skeletonize approximates the medial axis of a thin region by repeatedly
+// offsetting each of its contours inward by a small step and recording the
+// centerline of every contour at the depth it individually vanishes. The
+// depth a contour vanishes at is proportional to its local clearance, so it
+// becomes a medial polyline with a width of twice that depth - capped to
+// the usual variable-width range.
+//
+// This is not an exact medial-axis transform, but like PrusaSlicer/
+// SuperSlicer's gap-fill it doesn't need to be: it only has to find a path
+// through the thin region and a plausible width to extrude it with.
+func skeletonize(c clip.Clipper, region data.Paths, extrusionWidth data.Micrometer) []VariableWidthPolyline {
+	if len(region) == 0 {
+		return nil
+	}
+
+	step := extrusionWidth / skeletonizeSteps
+	minWidth := extrusionWidth / 2
+	maxWidth := (3 * extrusionWidth) / 2
+
+	var result []VariableWidthPolyline
+
+	active := make([]skeletonContour, len(region))
+	for i, path := range region {
+		active[i] = skeletonContour{path: path}
+	}
+
+	for depth := 1; depth <= skeletonizeSteps && len(active) > 0; depth++ {
+		width := 2 * data.Micrometer(depth) * step
+		if width < minWidth {
+			width = minWidth
+		}
+		if width > maxWidth {
+			width = maxWidth
+		}
+
+		var stillActive []skeletonContour
+		for _, ct := range active {
+			next := c.Offset(data.Paths{ct.path}, -step)
+
+			if len(next) == 0 {
+				// This contour vanished at this depth - its last surviving
+				// shape becomes a medial polyline at this depth's clearance.
+				result = append(result, centerlineOf(ct.path, width))
+				continue
+			}
+
+			// Offsetting one contour inward can split it (e.g. a
+			// dumbbell-shaped sliver pinching in the middle) - track every
+			// resulting piece as its own contour from here on.
+			for _, path := range next {
+				stillActive = append(stillActive, skeletonContour{path: path})
+			}
+		}
+		active = stillActive
+	}
+
+	return result
+}
+
+// centerlineOf approximates a thin closed contour's medial axis by pairing
+// each point with its mirror across the loop (point i with point
+// len(path)-1-i) and averaging the two. This is exact for a symmetric
+// ribbon shape and a reasonable approximation for the similarly-shaped
+// slivers gap-fill actually deals with, at a fraction of the cost of a true
+// medial-axis transform. Unlike the boundary contour itself, the result is
+// an open polyline running down the middle of the shape, not around its edge.
+func centerlineOf(path data.Path, width data.Micrometer) VariableWidthPolyline {
+	half := len(path) / 2
+
+	var line VariableWidthPolyline
+	for i := 0; i < half; i++ {
+		a := path[i]
+		b := path[len(path)-1-i]
+		mid := data.NewMicroPoint((a.X()+b.X())/2, (a.Y()+b.Y())/2)
+		line = append(line, VariableWidthPoint{Point: mid, Width: width})
+	}
+
+	return line
+}